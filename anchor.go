@@ -0,0 +1,195 @@
+package patcher
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// RewriteAnchored is like RewriteBytes, but additionally records the bytes
+// the caller expects to find at [offset, offset+len(expected)) in the
+// input. During PatchBytes, if those bytes have drifted (the input has
+// been edited since offset was recorded), the patch is transparently
+// shifted to the nearest unique occurrence of expected within the window
+// configured by SetAnchorSearch; if none or more than one is found,
+// PatchBytes returns an *AnchorMismatchError instead of silently
+// corrupting the output, as the unanchored methods would.
+//
+// expected and data must not be changed before the call to
+// PatchString/PatchBytes. Anchored patches participate in the same
+// sort/conflict machinery as any other patch.
+func (p *Patcher) RewriteAnchored(offset int, expected, data []byte) {
+	if len(expected) == 0 && len(data) == 0 {
+		return
+	}
+	p.patches = append(p.patches, patch{
+		offset: offset,
+		length: len(expected),
+		data:   data,
+		anchor: nonNil(expected),
+	})
+}
+
+// DeleteAnchored is like Delete, but verifies (and, if necessary, relocates)
+// the deletion the same way RewriteAnchored does. See RewriteAnchored for
+// details.
+func (p *Patcher) DeleteAnchored(offset int, expected []byte) {
+	if len(expected) == 0 {
+		return
+	}
+	p.patches = append(p.patches, patch{
+		offset: offset,
+		length: len(expected),
+		anchor: nonNil(expected),
+	})
+}
+
+// InsertAfter inserts data right after expected, which is verified (and,
+// if necessary, relocated) the same way RewriteAnchored verifies its
+// replaced content. It is equivalent to RewriteAnchored(offset, expected,
+// append(expected, data...)), i.e. a rewrite that reproduces expected
+// unchanged and appends data after it.
+func (p *Patcher) InsertAfter(offset int, expected, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	combined := make([]byte, 0, len(expected)+len(data))
+	combined = append(combined, expected...)
+	combined = append(combined, data...)
+	p.RewriteAnchored(offset, expected, combined)
+}
+
+// SetAnchorSearch configures how far (in bytes, in both directions) an
+// anchored patch is allowed to drift from its recorded offset when the
+// expected content is no longer found there. The default window is 0,
+// meaning anchored patches must match exactly where recorded.
+func (p *Patcher) SetAnchorSearch(window int) {
+	p.anchorWindow = window
+}
+
+// AnchorMismatchError is returned by PatchBytes when an anchored edit's
+// expected content is not found at its recorded offset, and the configured
+// search window (see SetAnchorSearch) does not contain exactly one
+// occurrence of it either.
+type AnchorMismatchError struct {
+	// Offset is the position the anchored patch was originally recorded at.
+	Offset int
+	// Expected is the content the patch expected to find there.
+	Expected []byte
+	// Actual is the content that was actually found there, for diagnostics.
+	// It is set in the ambiguous case too, since the search only runs after
+	// the content at Offset failed to match.
+	Actual []byte
+	// Ambiguous is true if the search window contained more than one
+	// occurrence of Expected, rather than none.
+	Ambiguous bool
+}
+
+func (e *AnchorMismatchError) Error() string {
+	if e.Ambiguous {
+		return fmt.Sprintf("anchor mismatch at %d: %q found more than once within the search window", e.Offset, e.Expected)
+	}
+	return fmt.Sprintf("anchor mismatch at %d: expected %q, got %q", e.Offset, e.Expected, e.Actual)
+}
+
+// resolveAnchors verifies every anchored patch in resolved against input,
+// relocating any whose expected content has drifted, and returns the
+// patches re-sorted by their (possibly updated) offsets.
+func (p *Patcher) resolveAnchors(input []byte, resolved []patch) ([]patch, error) {
+	shifted := false
+
+	for i, pt := range resolved {
+		if pt.anchor == nil {
+			continue
+		}
+
+		if bytesAt(input, pt.offset, pt.anchor) {
+			continue
+		}
+
+		offset, ambiguous := findAnchor(input, pt.anchor, pt.offset, p.anchorWindow)
+		if offset == -1 {
+			return nil, &AnchorMismatchError{
+				Offset:    pt.offset,
+				Expected:  pt.anchor,
+				Actual:    safeSlice(input, pt.offset, pt.offset+len(pt.anchor)),
+				Ambiguous: ambiguous,
+			}
+		}
+
+		resolved[i].offset = offset
+		shifted = true
+	}
+
+	if shifted {
+		sort.SliceStable(resolved, func(i, j int) bool {
+			return resolved[i].offset < resolved[j].offset
+		})
+	}
+
+	return resolved, nil
+}
+
+// findAnchor searches input for the unique occurrence of anchor closest to
+// offset, within [offset-window, offset+window]. It returns (-1, false) if
+// no occurrence was found in the window, or (-1, true) if more than one
+// was found.
+func findAnchor(input, anchor []byte, offset, window int) (found int, ambiguous bool) {
+	if len(anchor) == 0 {
+		return -1, false
+	}
+
+	lo := offset - window
+	if lo < 0 {
+		lo = 0
+	}
+	hi := offset + window
+	if hi > len(input)-len(anchor) {
+		hi = len(input) - len(anchor)
+	}
+
+	found = -1
+	for i := lo; i <= hi; i++ {
+		if bytes.Equal(input[i:i+len(anchor)], anchor) {
+			if found != -1 {
+				return -1, true
+			}
+			found = i
+		}
+	}
+	if found == -1 {
+		return -1, false
+	}
+	return found, false
+}
+
+func bytesAt(input []byte, offset int, expected []byte) bool {
+	end := offset + len(expected)
+	if offset < 0 || end > len(input) {
+		return false
+	}
+	return bytes.Equal(input[offset:end], expected)
+}
+
+func safeSlice(data []byte, lo, hi int) []byte {
+	if lo < 0 {
+		lo = 0
+	}
+	if lo > len(data) {
+		lo = len(data)
+	}
+	if hi > len(data) {
+		hi = len(data)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return data[lo:hi]
+}
+
+func nonNil(data []byte) []byte {
+	if data == nil {
+		return []byte{}
+	}
+	return data
+}
@@ -0,0 +1,77 @@
+package patcher
+
+import "testing"
+
+func TestRewriteAnchoredExactMatch(t *testing.T) {
+	input := "hello world"
+	var p Patcher
+	p.RewriteAnchored(6, []byte("world"), []byte("there"))
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "hello there")
+}
+
+func TestRewriteAnchoredShifted(t *testing.T) {
+	// Recorded against "hello world", but applied to an input where one
+	// byte was inserted before "world", shifting it by 1.
+	input := "hello, world"
+	var p Patcher
+	p.SetAnchorSearch(4)
+	p.RewriteAnchored(6, []byte("world"), []byte("there"))
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "hello, there")
+}
+
+func TestRewriteAnchoredNoSearchWindowFails(t *testing.T) {
+	input := "hello, world"
+	var p Patcher
+	p.RewriteAnchored(6, []byte("world"), []byte("there"))
+
+	_, err := p.PatchString(input)
+
+	if _, ok := err.(*AnchorMismatchError); !ok {
+		t.Fatalf("expected *AnchorMismatchError, got %T (%v)", err, err)
+	}
+}
+
+func TestRewriteAnchoredAmbiguous(t *testing.T) {
+	input := "world world world"
+	var p Patcher
+	p.SetAnchorSearch(20)
+	p.RewriteAnchored(9, []byte("world"), []byte("there"))
+
+	_, err := p.PatchString(input)
+
+	mismatch, ok := err.(*AnchorMismatchError)
+	if !ok {
+		t.Fatalf("expected *AnchorMismatchError, got %T (%v)", err, err)
+	}
+	if !mismatch.Ambiguous {
+		t.Fatalf("expected ambiguous mismatch, got %v", mismatch)
+	}
+}
+
+func TestDeleteAnchored(t *testing.T) {
+	input := "hello, world"
+	var p Patcher
+	p.SetAnchorSearch(4)
+	p.DeleteAnchored(6, []byte("world"))
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "hello, ")
+}
+
+func TestInsertAfter(t *testing.T) {
+	input := "hello, world"
+	var p Patcher
+	p.SetAnchorSearch(4)
+	p.InsertAfter(6, []byte("world"), []byte("!"))
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "hello, world!")
+}
@@ -0,0 +1,98 @@
+package patcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictError is returned when two recorded edits overlap and cannot be
+// applied together. It embeds both offending patches plus a rendered
+// excerpt of the input region they disagree about, covering
+// min(offset)..max(offset+length) of the two patches, so the conflict is
+// easy to read even for multi-byte rewrites.
+//
+// Error() keeps the original "conflict: (o1,l1,d1) vs (o2,l2,d2)" text, so
+// code that only inspects the error string keeps working; type-assert to
+// *ConflictError for the richer details.
+type ConflictError struct {
+	// A and B are the two conflicting patches, in the order they were
+	// found during the sorted scan (A comes first in the input).
+	A, B patch
+	// Region is the slice of the original input spanning both patches.
+	Region []byte
+
+	base int // offset of Region within the original input
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: %v vs %v", e.A, e.B)
+}
+
+// Unified renders a unified-diff-style excerpt showing the two outcomes
+// that conflict: Region with only A applied ("first edits"), and Region
+// with only B applied ("second edits") — the way go/analysis renders
+// overlapping suggested fixes. Each outcome is split and prefixed line by
+// line, so regions spanning more than one line render as valid diff text.
+func (e *ConflictError) Unified() string {
+	aLines := splitLines(e.outcome(e.A))
+	bLines := splitLines(e.outcome(e.B))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- first edits\n")
+	fmt.Fprintf(&buf, "+++ second edits\n")
+	fmt.Fprintf(&buf, "@@ -1,%d +1,%d @@\n", len(aLines), len(bLines))
+	for _, line := range aLines {
+		writeHunkLine(&buf, '-', line)
+	}
+	for _, line := range bLines {
+		writeHunkLine(&buf, '+', line)
+	}
+	return buf.String()
+}
+
+// outcome returns what Region would look like if only pt were applied to
+// it, ignoring the other conflicting patch.
+func (e *ConflictError) outcome(pt patch) []byte {
+	lo, hi := pt.offset-e.base, pt.offset+pt.length-e.base
+	if lo < 0 {
+		lo = 0
+	}
+	if lo > len(e.Region) {
+		lo = len(e.Region)
+	}
+	if hi > len(e.Region) {
+		hi = len(e.Region)
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	out := make([]byte, 0, lo+len(pt.data)+len(e.Region)-hi)
+	out = append(out, e.Region[:lo]...)
+	out = append(out, pt.data...)
+	out = append(out, e.Region[hi:]...)
+	return out
+}
+
+// newConflictError builds a ConflictError for two adjacent, overlapping
+// patches from a sorted scan, deriving Region from input.
+func newConflictError(input []byte, a, b patch) *ConflictError {
+	lo := a.offset
+	hi := a.offset + a.length
+	if end := b.offset + b.length; end > hi {
+		hi = end
+	}
+	if lo > len(input) {
+		lo = len(input)
+	}
+	if hi > len(input) {
+		hi = len(input)
+	}
+
+	return &ConflictError{
+		A:      a,
+		B:      b,
+		Region: append([]byte(nil), input[lo:hi]...),
+		base:   lo,
+	}
+}
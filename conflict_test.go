@@ -0,0 +1,90 @@
+package patcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConflictErrorType(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.RewriteString(0, 3, "XXX")
+	p.RewriteString(2, 3, "YYY")
+
+	_, err := p.PatchString(input)
+
+	checkError(t, "", err, "conflict", checkPatch(0, 3, "XXX"), checkPatch(2, 3, "YYY"))
+
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected *ConflictError, got %T", err)
+	}
+	if string(conflict.Region) != "abcde" {
+		t.Errorf("wrong region: %q", conflict.Region)
+	}
+
+	unified := conflict.Unified()
+	if !strings.Contains(unified, "first edits") || !strings.Contains(unified, "second edits") {
+		t.Errorf("expected unified excerpt to label both outcomes, got:\n%s", unified)
+	}
+	if !strings.Contains(unified, "XXXde") {
+		t.Errorf("expected unified excerpt to show first outcome, got:\n%s", unified)
+	}
+	if !strings.Contains(unified, "abYYY") {
+		t.Errorf("expected unified excerpt to show second outcome, got:\n%s", unified)
+	}
+}
+
+func TestConflictErrorMultiByteRewrite(t *testing.T) {
+	input := "The quick brown fox"
+	var p Patcher
+	p.RewriteString(4, 5, "slow ")
+	p.RewriteString(6, 8, "green turtle")
+
+	_, err := p.PatchString(input)
+
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected *ConflictError, got %T", err)
+	}
+	if string(conflict.Region) != "quick brow" {
+		t.Errorf("wrong region: %q", conflict.Region)
+	}
+
+	unified := conflict.Unified()
+	if !strings.Contains(unified, "slow ") || !strings.Contains(unified, "green turtle") {
+		t.Errorf("expected unified excerpt to show both rewrites, got:\n%s", unified)
+	}
+}
+
+func TestConflictErrorMultiLineRegion(t *testing.T) {
+	input := "line1\nline2\nline3\n"
+	var p Patcher
+	p.RewriteString(0, 12, "AAA")
+	p.RewriteString(6, 6, "BBB")
+
+	_, err := p.PatchString(input)
+
+	conflict, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected *ConflictError, got %T", err)
+	}
+
+	unified := conflict.Unified()
+	for _, line := range strings.Split(strings.TrimRight(unified, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "@@ "):
+		case line[0] == '-' || line[0] == '+':
+		default:
+			t.Errorf("line missing a diff prefix: %q\nfull output:\n%s", line, unified)
+		}
+	}
+
+	expected := "--- first edits\n+++ second edits\n@@ -1,1 +1,2 @@\n-AAA\n+line1\n+BBB\n"
+	if unified != expected {
+		t.Errorf("wrong unified excerpt:\nexpected: %q\nactual:   %q", expected, unified)
+	}
+}
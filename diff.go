@@ -0,0 +1,226 @@
+package patcher
+
+import "bytes"
+
+// Diff computes the edits required to turn a into b using the Myers
+// shortest-edit-script algorithm and returns a new Patcher with the
+// resulting Delete/InsertBytes/RewriteBytes calls already recorded.
+//
+// The returned Patcher behaves like any other: PatchBytes(a) reproduces b,
+// additional manual edits can be appended before patching, and the usual
+// conflict checks in PatchBytes still apply.
+//
+// Diff operates byte by byte. For large inputs, consider DiffLines, which
+// keeps the edit graph small by comparing whole lines instead.
+func Diff(a, b []byte) *Patcher {
+	return diff(a, b, false)
+}
+
+// DiffString is the string equivalent of Diff.
+func DiffString(a, b string) *Patcher {
+	return Diff([]byte(a), []byte(b))
+}
+
+// DiffLines is like Diff, but compares a and b line by line (splitting on
+// '\n', with the newline kept as part of the preceding line) instead of
+// byte by byte. This keeps the edit graph small for large inputs at the
+// cost of only ever producing patches aligned to line boundaries.
+func DiffLines(a, b []byte) *Patcher {
+	return diff(a, b, true)
+}
+
+// DiffLinesString is the string equivalent of DiffLines.
+func DiffLinesString(a, b string) *Patcher {
+	return DiffLines([]byte(a), []byte(b))
+}
+
+// opKind identifies one step of a Myers edit script.
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// diffOp is one step of a Myers edit script. ai/bi are token indices: for
+// opEqual and opDelete, ai is the index of the consumed token in a; for
+// opEqual and opInsert, bi is the index of the consumed token in b. For
+// opInsert, ai is the (unconsumed) position in a at which the insertion
+// happens.
+type diffOp struct {
+	kind   opKind
+	ai, bi int
+}
+
+func diff(a, b []byte, lineMode bool) *Patcher {
+	aStarts := tokenOffsets(a, lineMode)
+	bStarts := tokenOffsets(b, lineMode)
+	n, m := len(aStarts)-1, len(bStarts)-1
+
+	equal := func(i, j int) bool {
+		return bytes.Equal(a[aStarts[i]:aStarts[i+1]], b[bStarts[j]:bStarts[j+1]])
+	}
+
+	p := &Patcher{}
+
+	delFrom, delTo := -1, -1
+	insFrom, insTo := -1, -1
+	insAt := -1
+
+	flush := func() {
+		if delFrom == -1 && insFrom == -1 {
+			return
+		}
+
+		var offset, length int
+		if delFrom != -1 {
+			offset = aStarts[delFrom]
+			length = aStarts[delTo] - aStarts[delFrom]
+		} else {
+			offset = aStarts[insAt]
+		}
+
+		var data []byte
+		if insFrom != -1 {
+			data = b[bStarts[insFrom]:bStarts[insTo]]
+		}
+
+		switch {
+		case length != 0 && len(data) != 0:
+			p.RewriteBytes(offset, length, data)
+		case length != 0:
+			p.Delete(offset, length)
+		case len(data) != 0:
+			p.InsertBytes(offset, data)
+		}
+
+		delFrom, delTo = -1, -1
+		insFrom, insTo = -1, -1
+		insAt = -1
+	}
+
+	for _, op := range myersDiff(n, m, equal) {
+		switch op.kind {
+		case opEqual:
+			flush()
+		case opDelete:
+			if delFrom == -1 {
+				delFrom = op.ai
+			}
+			delTo = op.ai + 1
+		case opInsert:
+			if insFrom == -1 {
+				insFrom = op.bi
+				insAt = op.ai
+			}
+			insTo = op.bi + 1
+		}
+	}
+	flush()
+
+	return p
+}
+
+// tokenOffsets returns the token boundaries of data as offsets, such that
+// token i spans data[offsets[i]:offsets[i+1]]. In byte mode each token is a
+// single byte; in line mode each token is a line, including its trailing
+// '\n' if present.
+func tokenOffsets(data []byte, lineMode bool) []int {
+	if !lineMode {
+		offsets := make([]int, len(data)+1)
+		for i := range offsets {
+			offsets[i] = i
+		}
+		return offsets
+	}
+
+	offsets := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	if offsets[len(offsets)-1] != len(data) {
+		offsets = append(offsets, len(data))
+	}
+	return offsets
+}
+
+// myersDiff returns the shortest edit script that turns a sequence of n
+// tokens into a sequence of m tokens, given a function that reports whether
+// token i of the first sequence equals token j of the second. It implements
+// the algorithm described in Eugene W. Myers' "An O(ND) Difference
+// Algorithm and Its Variations".
+func myersDiff(n, m int, equal func(i, j int) bool) []diffOp {
+	max := n + m
+
+	v := map[int]int{1: 0}
+	var trace []map[int]int
+
+	d := 0
+outer:
+	for ; d <= max; d++ {
+		snapshot := make(map[int]int, len(v))
+		for k, x := range v {
+			snapshot[k] = x
+		}
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1] < v[k+1]) {
+				x = v[k+1]
+			} else {
+				x = v[k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && equal(x, y) {
+				x++
+				y++
+			}
+
+			v[k] = x
+			if x >= n && y >= m {
+				break outer
+			}
+		}
+	}
+
+	var ops []diffOp
+	x, y := n, m
+	for ; d >= 0; d-- {
+		snapshot := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && snapshot[k-1] < snapshot[k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := snapshot[prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: opEqual, ai: x - 1, bi: y - 1})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{kind: opInsert, ai: x, bi: y - 1})
+			} else {
+				ops = append(ops, diffOp{kind: opDelete, ai: x - 1, bi: y})
+			}
+			x, y = prevX, prevY
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
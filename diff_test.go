@@ -0,0 +1,96 @@
+package patcher
+
+import "testing"
+
+func TestDiffIdentical(t *testing.T) {
+	p := DiffString("abc", "abc")
+
+	if !p.Empty() {
+		t.Fatal("expected empty patcher")
+	}
+
+	actual, err := p.PatchString("abc")
+
+	checkOutput(t, actual, err, "abc")
+}
+
+func TestDiffInsert(t *testing.T) {
+	p := DiffString("ac", "abc")
+
+	actual, err := p.PatchString("ac")
+
+	checkOutput(t, actual, err, "abc")
+}
+
+func TestDiffDelete(t *testing.T) {
+	p := DiffString("abc", "ac")
+
+	actual, err := p.PatchString("abc")
+
+	checkOutput(t, actual, err, "ac")
+}
+
+func TestDiffRewrite(t *testing.T) {
+	p := DiffString("abc", "axc")
+
+	actual, err := p.PatchString("abc")
+
+	checkOutput(t, actual, err, "axc")
+}
+
+func TestDiffExample(t *testing.T) {
+	a := "The brown fox jumps twice over the lazy horse"
+	b := "The quick brown fox jumps over the lazy dog"
+
+	p := DiffString(a, b)
+
+	actual, err := p.PatchString(a)
+
+	checkOutput(t, actual, err, b)
+}
+
+func TestDiffEmptyInputs(t *testing.T) {
+	p := DiffString("", "")
+
+	if !p.Empty() {
+		t.Fatal("expected empty patcher")
+	}
+}
+
+func TestDiffFromEmpty(t *testing.T) {
+	p := DiffString("", "abc")
+
+	actual, err := p.PatchString("")
+
+	checkOutput(t, actual, err, "abc")
+}
+
+func TestDiffToEmpty(t *testing.T) {
+	p := DiffString("abc", "")
+
+	actual, err := p.PatchString("abc")
+
+	checkOutput(t, actual, err, "")
+}
+
+func TestDiffLines(t *testing.T) {
+	a := "one\ntwo\nthree\n"
+	b := "one\ntwo and a half\nthree\n"
+
+	p := DiffLinesString(a, b)
+
+	actual, err := p.PatchString(a)
+
+	checkOutput(t, actual, err, b)
+}
+
+func TestDiffLinesNoTrailingNewline(t *testing.T) {
+	a := "one\ntwo"
+	b := "one\ntwo\nthree"
+
+	p := DiffLinesString(a, b)
+
+	actual, err := p.PatchString(a)
+
+	checkOutput(t, actual, err, b)
+}
@@ -17,16 +17,45 @@ type patch struct {
 	length int
 	// data is the new data that should be inserted.
 	data []byte
+
+	// anchor, if non-nil, is the content an anchored patch expects to find
+	// at offset (see RewriteAnchored). It is always len(anchor) == length.
+	anchor []byte
 }
 
 func (p patch) String() string {
 	return fmt.Sprintf("(%d,%d,%s)", p.offset, p.length, p.data)
 }
 
+// PatcherOptions configures how a Patcher interprets the offsets and
+// lengths passed to Delete/InsertBytes/RewriteBytes and friends.
+type PatcherOptions struct {
+	// LineMode causes offsets and lengths to be interpreted as 0-based line
+	// numbers and line counts instead of byte offsets, with lines split on
+	// '\n'. ParseUnified returns Patchers with this set, since unified diffs
+	// are inherently line-oriented even though the rest of this package is
+	// byte-oriented.
+	LineMode bool
+}
+
 // Patcher is used to record and apply the edits.
 // Do not copy a non-zero Patcher.
 type Patcher struct {
-	patches []patch
+	patches      []patch
+	options      PatcherOptions
+	policy       ConflictPolicy
+	anchorWindow int
+
+	// hunkContexts is populated by ParseUnified; resolve checks each entry
+	// against input so applying a diff to content it no longer matches
+	// fails loudly instead of silently rewriting the wrong lines.
+	hunkContexts []hunkContext
+}
+
+// SetOptions configures how p interprets offsets and lengths. The zero
+// value (byte offsets) applies until SetOptions is called.
+func (p *Patcher) SetOptions(options PatcherOptions) {
+	p.options = options
 }
 
 // Returns true if the patcher does not contain any edits.
@@ -120,11 +149,68 @@ func (p *Patcher) PatchString(input string) (string, error) {
 // (<offset>,<length-to-replace>,<text-to-insert>), e.g. (10,5,) for a
 // Delete(10, 5) and (5,0,foo) for a InsertString(5, "foo").
 func (p *Patcher) PatchBytes(input []byte) ([]byte, error) {
+	resolved, err := p.resolve(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	var cursor int
+	for _, patch := range resolved {
+		output.Write(input[cursor:patch.offset])
+		output.Write(patch.data)
+		cursor = patch.offset + patch.length
+	}
+	output.Write(input[cursor:])
+	return output.Bytes(), nil
+}
+
+// resolve sorts the recorded patches (in place, as PatchBytes has always
+// done), translates them to byte offsets against input (honoring
+// LineMode), and checks the result for range and conflict errors. It
+// returns a new slice; p.patches itself keeps its original offsets and
+// lengths, so a LineMode Patcher can still be replayed against other
+// inputs.
+func (p *Patcher) resolve(input []byte) ([]patch, error) {
 	sort.SliceStable(p.patches, func(i, j int) bool {
 		return p.patches[i].offset < p.patches[j].offset
 	})
 
-	for i, patch := range p.patches {
+	resolved := make([]patch, len(p.patches))
+	copy(resolved, p.patches)
+
+	if p.options.LineMode {
+		lineStarts := tokenOffsets(input, true)
+		for i, patch := range resolved {
+			if patch.offset < 0 || patch.offset > len(lineStarts)-1 || patch.offset+patch.length > len(lineStarts)-1 {
+				return nil, fmt.Errorf("out of range: %v", patch)
+			}
+			resolved[i].offset = lineStarts[patch.offset]
+			resolved[i].length = lineStarts[patch.offset+patch.length] - lineStarts[patch.offset]
+		}
+
+		for _, hc := range p.hunkContexts {
+			if hc.offset < 0 || hc.offset > len(lineStarts)-1 || hc.offset+hc.length > len(lineStarts)-1 {
+				return nil, fmt.Errorf("out of range: hunk context (%d,%d)", hc.offset, hc.length)
+			}
+			lo, hi := lineStarts[hc.offset], lineStarts[hc.offset+hc.length]
+			if !bytes.Equal(input[lo:hi], hc.text) {
+				return nil, &ContextMismatchError{
+					Offset:   hc.offset,
+					Length:   hc.length,
+					Expected: hc.text,
+					Actual:   append([]byte(nil), input[lo:hi]...),
+				}
+			}
+		}
+	}
+
+	resolved, err := p.resolveAnchors(input, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patch := range resolved {
 		if patch.offset < 0 {
 			return nil, fmt.Errorf("negative offset: %v", patch)
 		}
@@ -134,22 +220,46 @@ func (p *Patcher) PatchBytes(input []byte) ([]byte, error) {
 		if patch.offset+patch.length > len(input) {
 			return nil, fmt.Errorf("out of range: %v", patch)
 		}
+	}
+
+	return p.resolveConflicts(resolved, func(a, b patch) error {
+		return newConflictError(input, a, b)
+	})
+}
+
+// resolveConflicts scans sorted patches for overlaps and applies p.policy
+// to each one found (dropping or merging patches as appropriate), calling
+// conflictErr to build the error for PolicyStrict and for conflicts
+// PolicyMerge cannot reconcile.
+func (p *Patcher) resolveConflicts(resolved []patch, conflictErr func(a, b patch) error) ([]patch, error) {
+	i := 0
+	for i+1 < len(resolved) {
+		a, b := resolved[i], resolved[i+1]
+		if a.offset+a.length <= b.offset {
+			i++
+			continue
+		}
 
-		if i+1 < len(p.patches) {
-			next := p.patches[i+1]
-			if patch.offset+patch.length > next.offset {
-				return nil, fmt.Errorf("conflict: %v vs %v", patch, next)
+		switch p.policy {
+		case PolicyLastWins:
+			resolved = append(resolved[:i], resolved[i+1:]...)
+		case PolicyFirstWins:
+			resolved = append(resolved[:i+1], resolved[i+2:]...)
+		case PolicyMerge:
+			merged, ok := mergePatches(a, b)
+			if !ok {
+				return nil, conflictErr(a, b)
 			}
+			resolved[i] = merged
+			resolved = append(resolved[:i+1], resolved[i+2:]...)
+		default: // PolicyStrict
+			return nil, conflictErr(a, b)
 		}
-	}
 
-	var output bytes.Buffer
-	var cursor int
-	for _, patch := range p.patches {
-		output.Write(input[cursor:patch.offset])
-		output.Write(patch.data)
-		cursor = patch.offset + patch.length
+		if i > 0 {
+			i--
+		}
 	}
-	output.Write(input[cursor:])
-	return output.Bytes(), nil
+
+	return resolved, nil
 }
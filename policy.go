@@ -0,0 +1,118 @@
+package patcher
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ConflictPolicy controls how PatchBytes and WriteUnified handle
+// overlapping edits.
+type ConflictPolicy int
+
+const (
+	// PolicyStrict fails with a *ConflictError on any overlapping edits.
+	// This is the default (the zero value).
+	PolicyStrict ConflictPolicy = iota
+	// PolicyLastWins keeps the later (higher-offset) of two conflicting
+	// patches and drops the other.
+	PolicyLastWins
+	// PolicyFirstWins keeps the earlier (lower-offset) of two conflicting
+	// patches and drops the other.
+	PolicyFirstWins
+	// PolicyMerge attempts to reconcile overlapping patches: two
+	// overlapping rewrites (or deletes) whose data agrees over the
+	// overlapping region are fused into a single patch spanning their
+	// union. Overlapping inserts at the same offset are concatenated in
+	// recording order; this already happens regardless of policy, since
+	// zero-length inserts never trigger the conflict check. Edits
+	// PolicyMerge cannot reconcile fall back to PolicyStrict.
+	PolicyMerge
+)
+
+// SetPolicy configures how p resolves overlapping edits during
+// PatchBytes/WriteUnified. The zero value is PolicyStrict.
+func (p *Patcher) SetPolicy(policy ConflictPolicy) {
+	p.policy = policy
+}
+
+// Conflicts reports every conflict among the currently recorded patches
+// when applied to input, regardless of the current ConflictPolicy. Unlike
+// PatchBytes, it does not stop at the first conflict and does not mutate p,
+// so tooling can present every problem to the user at once.
+//
+// Like PatchBytes, it resolves anchored patches (see RewriteAnchored)
+// against input before scanning for overlaps, so its results agree with
+// what PatchBytes would actually do. If an anchored patch can't be
+// resolved (its expected content is missing or ambiguous), Conflicts
+// leaves it at its originally recorded offset rather than reporting that
+// failure itself; call PatchBytes to surface the *AnchorMismatchError.
+func (p *Patcher) Conflicts(input []byte) []*ConflictError {
+	patches := make([]patch, len(p.patches))
+	copy(patches, p.patches)
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i].offset < patches[j].offset
+	})
+
+	if p.options.LineMode {
+		lineStarts := tokenOffsets(input, true)
+		for i, patch := range patches {
+			if patch.offset < 0 || patch.offset > len(lineStarts)-1 || patch.offset+patch.length > len(lineStarts)-1 {
+				continue
+			}
+			patches[i].offset = lineStarts[patch.offset]
+			patches[i].length = lineStarts[patch.offset+patch.length] - lineStarts[patch.offset]
+		}
+	}
+
+	if resolved, err := p.resolveAnchors(input, patches); err == nil {
+		patches = resolved
+	}
+
+	var conflicts []*ConflictError
+	for i := 0; i+1 < len(patches); i++ {
+		a, b := patches[i], patches[i+1]
+		if a.offset+a.length > b.offset {
+			conflicts = append(conflicts, newConflictError(input, a, b))
+		}
+	}
+	return conflicts
+}
+
+// mergePatches attempts to fuse two overlapping patches (a before or equal
+// to b, per sorted order) into one spanning their union, for PolicyMerge.
+// It only succeeds if the two patches agree on the bytes they both place
+// in the overlapping region.
+func mergePatches(a, b patch) (patch, bool) {
+	aEnd := a.offset + a.length
+	bEnd := b.offset + b.length
+
+	overlap := aEnd - b.offset
+	if overlap <= 0 {
+		return patch{}, false
+	}
+
+	if len(a.data) == 0 && len(b.data) == 0 {
+		end := aEnd
+		if bEnd > end {
+			end = bEnd
+		}
+		return patch{offset: a.offset, length: end - a.offset}, true
+	}
+
+	aStart := b.offset - a.offset
+	if aStart < 0 || aStart+overlap > len(a.data) || overlap > len(b.data) {
+		return patch{}, false
+	}
+	if !bytes.Equal(a.data[aStart:aStart+overlap], b.data[:overlap]) {
+		return patch{}, false
+	}
+
+	end := aEnd
+	data := append([]byte(nil), a.data...)
+	if bEnd > aEnd {
+		end = bEnd
+		data = append(data, b.data[overlap:]...)
+	}
+
+	return patch{offset: a.offset, length: end - a.offset, data: data}, true
+}
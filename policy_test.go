@@ -0,0 +1,112 @@
+package patcher
+
+import "testing"
+
+func TestPolicyStrictIsDefault(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.RewriteString(0, 2, "x")
+	p.RewriteString(1, 1, "yz")
+
+	_, err := p.PatchString(input)
+
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("expected *ConflictError, got %T", err)
+	}
+}
+
+func TestPolicyLastWins(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.SetPolicy(PolicyLastWins)
+	p.RewriteString(0, 2, "x")
+	p.RewriteString(1, 1, "yz")
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "ayzcde")
+}
+
+func TestPolicyFirstWins(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.SetPolicy(PolicyFirstWins)
+	p.RewriteString(0, 2, "x")
+	p.RewriteString(1, 1, "yz")
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "xcde")
+}
+
+func TestPolicyMergeAgreeingRewrites(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.SetPolicy(PolicyMerge)
+	p.RewriteString(0, 3, "xyz")
+	p.RewriteString(1, 3, "yzw")
+
+	actual, err := p.PatchString(input)
+
+	checkOutput(t, actual, err, "xyzwe")
+}
+
+func TestPolicyMergeIncompatibleFallsBackToStrict(t *testing.T) {
+	input := "abcde"
+	var p Patcher
+	p.SetPolicy(PolicyMerge)
+	p.RewriteString(0, 3, "xyz")
+	p.RewriteString(1, 3, "qqq")
+
+	_, err := p.PatchString(input)
+
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("expected *ConflictError, got %T", err)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	input := "abcdefgh"
+	var p Patcher
+	p.RewriteString(0, 2, "x")
+	p.RewriteString(1, 2, "y")
+	p.RewriteString(5, 2, "z")
+	p.RewriteString(6, 2, "w")
+
+	conflicts := p.Conflicts([]byte(input))
+
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %d: %v", len(conflicts), conflicts)
+	}
+
+	// Conflicts must not mutate the Patcher or its policy-free behavior.
+	_, err := p.PatchString(input)
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("expected *ConflictError from PatchString, got %T", err)
+	}
+}
+
+func TestConflictsResolvesAnchors(t *testing.T) {
+	input := "0123456789XYZ0123456789"
+	var p Patcher
+	p.SetAnchorSearch(15)
+	// Recorded at offset 0, but "XYZ" is only found (uniquely) at offset 10.
+	p.RewriteAnchored(0, []byte("XYZ"), []byte("MMM"))
+	// Overlaps the stale recorded offset (0-2), but not the resolved one (10-12).
+	p.RewriteString(1, 1, "Q")
+
+	conflicts := p.Conflicts([]byte(input))
+
+	if len(conflicts) != 0 {
+		t.Fatalf("expected 0 conflicts once anchors are resolved, got %d: %v", len(conflicts), conflicts)
+	}
+
+	// Conflicts' answer must agree with what PatchBytes actually does.
+	_, err := p.PatchString(input)
+	if err != nil {
+		t.Fatalf("expected PatchString to succeed, got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package patcher
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Apply streams the recorded edits from src to dst. size is the length of
+// the input and is used to check the edits' ranges before anything is
+// written; after the last edit, the rest of src is copied until EOF.
+// Unlike PatchBytes, it never materializes src in a bytes.Buffer, which
+// makes it suitable for multi-gigabyte files or network streams.
+//
+// Apply does not support PatcherOptions.LineMode or anchored edits (see
+// RewriteAnchored), since both require inspecting the input bytes to
+// resolve offsets; use PatchBytes for those. The edits remain in the
+// Patcher and can be applied to other inputs.
+func (p *Patcher) Apply(dst io.Writer, src io.Reader, size int64) error {
+	resolved, err := p.resolveStream(size)
+	if err != nil {
+		return err
+	}
+
+	var cursor int64
+	for _, patch := range resolved {
+		if _, err := io.CopyN(dst, src, int64(patch.offset)-cursor); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(io.Discard, src, int64(patch.length)); err != nil {
+			return err
+		}
+		if _, err := dst.Write(patch.data); err != nil {
+			return err
+		}
+		cursor = int64(patch.offset + patch.length)
+	}
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func (p *Patcher) resolveStream(size int64) ([]patch, error) {
+	if p.options.LineMode {
+		return nil, fmt.Errorf("patcher: Apply does not support LineMode")
+	}
+
+	sort.SliceStable(p.patches, func(i, j int) bool {
+		return p.patches[i].offset < p.patches[j].offset
+	})
+
+	resolved := make([]patch, len(p.patches))
+	copy(resolved, p.patches)
+
+	for _, patch := range resolved {
+		if patch.anchor != nil {
+			return nil, fmt.Errorf("patcher: Apply does not support anchored edits: %v", patch)
+		}
+		if patch.offset < 0 {
+			return nil, fmt.Errorf("negative offset: %v", patch)
+		}
+		if patch.length < 0 {
+			return nil, fmt.Errorf("negative length: %v", patch)
+		}
+		if int64(patch.offset+patch.length) > size {
+			return nil, fmt.Errorf("out of range: %v", patch)
+		}
+	}
+
+	return p.resolveConflicts(resolved, func(a, b patch) error {
+		return fmt.Errorf("conflict: %v vs %v", a, b)
+	})
+}
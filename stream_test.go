@@ -0,0 +1,77 @@
+package patcher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	input := "The brown fox jumps twice over the lazy horse"
+
+	var p Patcher
+	p.InsertString(3, " quick")
+	p.Delete(strings.Index(input, "twice "), len("twice "))
+	p.RewriteString(40, 5, "dog")
+
+	var out bytes.Buffer
+	err := p.Apply(&out, strings.NewReader(input), int64(len(input)))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "The quick brown fox jumps over the lazy dog"
+	if out.String() != expected {
+		t.Errorf("wrong result:\nexpected: %s\nactual:   %s", expected, out.String())
+	}
+}
+
+func TestApplyOutOfRange(t *testing.T) {
+	var p Patcher
+	p.Delete(10, 1)
+
+	var out bytes.Buffer
+	err := p.Apply(&out, strings.NewReader("abcde"), 5)
+
+	if err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatal("expected out of range error, got:", err)
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	var p Patcher
+	p.RewriteString(0, 2, "x")
+	p.RewriteString(1, 1, "yz")
+
+	var out bytes.Buffer
+	err := p.Apply(&out, strings.NewReader("abc"), 3)
+
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Fatal("expected conflict error, got:", err)
+	}
+}
+
+func TestApplyRejectsLineMode(t *testing.T) {
+	var p Patcher
+	p.SetOptions(PatcherOptions{LineMode: true})
+	p.Delete(0, 1)
+
+	var out bytes.Buffer
+	err := p.Apply(&out, strings.NewReader("a\nb\n"), 4)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestApplyRejectsAnchored(t *testing.T) {
+	var p Patcher
+	p.RewriteAnchored(0, []byte("a"), []byte("x"))
+
+	var out bytes.Buffer
+	err := p.Apply(&out, strings.NewReader("abc"), 3)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,397 @@
+package patcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// noNewlineMarker is the standard unified-diff marker that follows a body
+// line which does not end in '\n' in the file it belongs to.
+const noNewlineMarker = "\\ No newline at end of file"
+
+// WriteUnified writes the recorded edits to w as a unified diff (the format
+// produced by diff -u and understood by patch(1) and git apply), computed
+// by applying the edits to input. context controls how many unchanged
+// lines of context are kept around each change, and how close two changes
+// need to be before they are merged into the same hunk.
+//
+// Like PatchBytes, WriteUnified reports range and conflict errors found
+// while resolving the recorded edits against input.
+func (p *Patcher) WriteUnified(w io.Writer, input []byte, context int) error {
+	resolved, err := p.resolve(input)
+	if err != nil {
+		return err
+	}
+	if len(resolved) == 0 {
+		return nil
+	}
+
+	lineStarts := tokenOffsets(input, true)
+	numLines := len(lineStarts) - 1
+
+	lineAt := func(offset int) int {
+		if numLines == 0 {
+			return 0
+		}
+		idx := sort.Search(numLines, func(i int) bool { return lineStarts[i+1] > offset })
+		if idx >= numLines {
+			idx = numLines - 1
+		}
+		return idx
+	}
+
+	// groupItem keeps each patch's own [lo,hi) line range around, so that
+	// unchanged lines between two non-contiguous patches merged into the
+	// same group can still be rendered as context instead of being
+	// flattened into the group's whole span.
+	type groupItem struct {
+		patch  patch
+		lo, hi int
+	}
+	type group struct {
+		items  []groupItem
+		lo, hi int // 0-based original line range [lo,hi) touched by the edits
+	}
+
+	var groups []group
+	for _, pt := range resolved {
+		lo := lineAt(pt.offset)
+		hi := lo + 1
+		if pt.length > 0 {
+			hi = lineAt(pt.offset+pt.length-1) + 1
+		} else if pt.offset == lineStarts[lo] {
+			// A pure insert exactly at a line boundary doesn't touch the
+			// original line at lo; it only adds new lines before it.
+			hi = lo
+		}
+		if hi > numLines {
+			hi = numLines
+		}
+
+		item := groupItem{patch: pt, lo: lo, hi: hi}
+		if n := len(groups); n > 0 && lo <= groups[n-1].hi+2*context {
+			groups[n-1].items = append(groups[n-1].items, item)
+			if hi > groups[n-1].hi {
+				groups[n-1].hi = hi
+			}
+		} else {
+			groups = append(groups, group{items: []groupItem{item}, lo: lo, hi: hi})
+		}
+	}
+
+	type outLine struct {
+		prefix byte
+		text   []byte
+	}
+
+	delta := 0
+	for gi, g := range groups {
+		hunkLo := g.lo - context
+		if hunkLo < 0 {
+			hunkLo = 0
+		}
+		hunkHi := g.hi + context
+		if hunkHi > numLines {
+			hunkHi = numLines
+		}
+
+		// Walk the group's items in order, emitting the unchanged lines
+		// before each one as context and only the lines it actually
+		// touches as '-'/'+', instead of flattening the whole [g.lo,g.hi)
+		// span into one undifferentiated replace.
+		var lines []outLine
+		cursor := hunkLo
+		for _, item := range g.items {
+			for i := cursor; i < item.lo; i++ {
+				lines = append(lines, outLine{' ', input[lineStarts[i]:lineStarts[i+1]]})
+			}
+			for i := item.lo; i < item.hi; i++ {
+				lines = append(lines, outLine{'-', input[lineStarts[i]:lineStarts[i+1]]})
+			}
+
+			var newSegment bytes.Buffer
+			segCursor := lineStarts[item.lo]
+			newSegment.Write(input[segCursor:item.patch.offset])
+			newSegment.Write(item.patch.data)
+			segCursor = item.patch.offset + item.patch.length
+			newSegment.Write(input[segCursor:lineStarts[item.hi]])
+			for _, line := range splitLines(newSegment.Bytes()) {
+				lines = append(lines, outLine{'+', line})
+			}
+
+			cursor = item.hi
+		}
+		for i := cursor; i < hunkHi; i++ {
+			lines = append(lines, outLine{' ', input[lineStarts[i]:lineStarts[i+1]]})
+		}
+
+		origLen, newLen := 0, 0
+		for _, l := range lines {
+			if l.prefix != '+' {
+				origLen++
+			}
+			if l.prefix != '-' {
+				newLen++
+			}
+		}
+
+		origStart, newStart := hunkLo+1, hunkLo+1+delta
+		if origLen == 0 {
+			origStart = hunkLo
+		}
+		if newLen == 0 {
+			newStart = hunkLo + delta
+		}
+
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", origStart, origLen, newStart, newLen)
+
+		// Only the last group can possibly reach the true end of input
+		// (groups are built in increasing offset order, so an earlier
+		// group's hunkHi is always bounded below the next group's lo).
+		// Within it, the last non-'+' line is the old file's real last
+		// line, and the last non-'-' line is the new file's real last
+		// line; either (or both, or neither) may lack a trailing newline.
+		lastOld, lastNew := -1, -1
+		if gi == len(groups)-1 && hunkHi == numLines {
+			for i := len(lines) - 1; i >= 0 && (lastOld == -1 || lastNew == -1); i-- {
+				if lastOld == -1 && lines[i].prefix != '+' {
+					lastOld = i
+				}
+				if lastNew == -1 && lines[i].prefix != '-' {
+					lastNew = i
+				}
+			}
+		}
+
+		for i, l := range lines {
+			writeHunkLine(w, l.prefix, l.text)
+			if (i == lastOld || i == lastNew) && !bytes.HasSuffix(l.text, []byte("\n")) {
+				fmt.Fprintln(w, noNewlineMarker)
+			}
+		}
+
+		delta += newLen - origLen
+	}
+
+	return nil
+}
+
+func writeHunkLine(w io.Writer, prefix byte, line []byte) {
+	fmt.Fprintf(w, "%c%s\n", prefix, bytes.TrimSuffix(line, []byte("\n")))
+}
+
+func splitLines(data []byte) [][]byte {
+	starts := tokenOffsets(data, true)
+	lines := make([][]byte, len(starts)-1)
+	for i := range lines {
+		lines[i] = data[starts[i]:starts[i+1]]
+	}
+	return lines
+}
+
+// hunkContext records the old-side text a parsed hunk expects to find at
+// [offset, offset+length) (0-based lines), so resolve can verify the input
+// hasn't drifted away from the diff's context, the way patch(1) does.
+type hunkContext struct {
+	offset, length int
+	text           []byte
+}
+
+// ContextMismatchError is returned by PatchBytes/WriteUnified when a
+// Patcher built by ParseUnified is applied to input whose content no
+// longer matches the context and removed lines recorded in the diff at a
+// hunk's original line range.
+type ContextMismatchError struct {
+	// Offset and Length are the 0-based line range the hunk expected to match.
+	Offset, Length int
+	// Expected is the context/removed text the diff recorded for that range.
+	Expected []byte
+	// Actual is the text actually found there, for diagnostics.
+	Actual []byte
+}
+
+func (e *ContextMismatchError) Error() string {
+	return fmt.Sprintf("unified diff: input does not match hunk context at line %d: expected %q, got %q", e.Offset+1, e.Expected, e.Actual)
+}
+
+// ParseUnified parses a unified diff as produced by WriteUnified (or
+// diff -u) and returns a Patcher that records the hunks as
+// Delete/InsertBytes/RewriteBytes calls.
+//
+// Unified diffs only carry line numbers, not byte offsets, so the returned
+// Patcher has PatcherOptions{LineMode: true} set: offsets and lengths are
+// 0-based line numbers and line counts. It can be replayed against any
+// input whose lines still match the context/removed lines of the diff;
+// PatchBytes/WriteUnified verify this (returning *ContextMismatchError if
+// the input has drifted) before applying, and it also participates in the
+// usual sort/conflict checks.
+func ParseUnified(r io.Reader) (*Patcher, error) {
+	p := &Patcher{}
+	p.SetOptions(PatcherOptions{LineMode: true})
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	ok := scanner.Scan()
+	var line string
+	if ok {
+		line = scanner.Text()
+	}
+
+	for ok {
+		if !strings.HasPrefix(line, "@@ ") {
+			return nil, fmt.Errorf("unified diff: expected hunk header, got %q", line)
+		}
+		origStart, _, _, _, err := parseHunkHeader(line)
+		if err != nil {
+			return nil, err
+		}
+
+		origLine := origStart
+		delFrom, delTo := -1, -1
+		var insData []byte
+		var oldText []byte // reconstructed context+removed text for the whole hunk
+
+		// lastAppended points at whichever of insData/oldText the most
+		// recently read hunk line appended to, so a following "\ No
+		// newline at end of file" marker can strip the trailing '\n' we
+		// optimistically added after that one line.
+		var lastAppended *[]byte
+
+		flush := func() {
+			if delFrom == -1 && len(insData) == 0 {
+				return
+			}
+			offset, length := origLine, 0
+			if delFrom != -1 {
+				offset, length = delFrom, delTo-delFrom
+			}
+			switch {
+			case length != 0 && len(insData) != 0:
+				p.RewriteBytes(offset, length, insData)
+			case length != 0:
+				p.Delete(offset, length)
+			case len(insData) != 0:
+				p.InsertBytes(offset, insData)
+			}
+			delFrom, delTo = -1, -1
+			insData = nil
+			lastAppended = nil
+		}
+
+		for {
+			ok = scanner.Scan()
+			if !ok {
+				break
+			}
+			line = scanner.Text()
+			if strings.HasPrefix(line, "@@ ") {
+				break
+			}
+			if line == "" {
+				return nil, fmt.Errorf("unified diff: empty hunk line")
+			}
+
+			if line[0] == '\\' {
+				// "\ No newline at end of file": the line it follows has
+				// no trailing newline in whichever file it belongs to.
+				if lastAppended != nil {
+					*lastAppended = bytes.TrimSuffix(*lastAppended, []byte("\n"))
+					lastAppended = nil
+				}
+				continue
+			}
+
+			switch line[0] {
+			case ' ':
+				flush()
+				oldText = append(oldText, []byte(line[1:])...)
+				oldText = append(oldText, '\n')
+				lastAppended = &oldText
+				origLine++
+			case '-':
+				if delFrom == -1 {
+					delFrom = origLine
+				}
+				oldText = append(oldText, []byte(line[1:])...)
+				oldText = append(oldText, '\n')
+				lastAppended = &oldText
+				origLine++
+				delTo = origLine
+			case '+':
+				insData = append(insData, []byte(line[1:])...)
+				insData = append(insData, '\n')
+				lastAppended = &insData
+			default:
+				return nil, fmt.Errorf("unified diff: invalid hunk line %q", line)
+			}
+		}
+		flush()
+
+		if origLine > origStart {
+			p.hunkContexts = append(p.hunkContexts, hunkContext{
+				offset: origStart,
+				length: origLine - origStart,
+				text:   oldText,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func parseHunkHeader(line string) (origStart, origLen, newStart, newLen int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "@@" || fields[3] != "@@" {
+		return 0, 0, 0, 0, fmt.Errorf("unified diff: invalid hunk header %q", line)
+	}
+
+	origStart, origLen, err = parseHunkRange(fields[1], '-')
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	newStart, newLen, err = parseHunkRange(fields[2], '+')
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return origStart, origLen, newStart, newLen, nil
+}
+
+// parseHunkRange parses one side of a hunk header, e.g. "-12,4", returning
+// a 0-based line index and a line count. Following GNU conventions, a
+// range with length 0 anchors start to the 0-based line before which the
+// empty side is positioned, so start needs no further adjustment in that
+// case; otherwise start is 1-based in the header and is converted to 0.
+func parseHunkRange(field string, prefix byte) (start, length int, err error) {
+	if len(field) == 0 || field[0] != prefix {
+		return 0, 0, fmt.Errorf("unified diff: invalid range %q", field)
+	}
+
+	parts := strings.SplitN(field[1:], ",", 2)
+	start, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unified diff: invalid range %q", field)
+	}
+
+	length = 1
+	if len(parts) == 2 {
+		length, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("unified diff: invalid range %q", field)
+		}
+	}
+
+	if length != 0 {
+		start--
+	}
+	return start, length, nil
+}
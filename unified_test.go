@@ -0,0 +1,184 @@
+package patcher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnifiedRewrite(t *testing.T) {
+	input := "one\ntwo\nthree\nfour\nfive\n"
+
+	var p Patcher
+	p.RewriteString(4, 3, "TWO")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(input), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n"
+	if buf.String() != expected {
+		t.Errorf("wrong diff:\nexpected: %q\nactual:   %q", expected, buf.String())
+	}
+}
+
+func TestWriteUnifiedInsert(t *testing.T) {
+	input := "one\ntwo\n"
+
+	var p Patcher
+	p.InsertString(4, "one and a half\n")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(input), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n"
+	if buf.String() != expected {
+		t.Errorf("wrong diff:\nexpected: %q\nactual:   %q", expected, buf.String())
+	}
+}
+
+func TestWriteUnifiedNoEdits(t *testing.T) {
+	var p Patcher
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte("abc"), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected empty diff, got %q", buf.String())
+	}
+}
+
+func TestParseUnifiedRoundTrip(t *testing.T) {
+	input := "one\ntwo\nthree\nfour\nfive\n"
+
+	var p Patcher
+	p.RewriteString(4, 3, "TWO")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(input), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	parsed, err := ParseUnified(&buf)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	actual, err := parsed.PatchString(input)
+
+	checkOutput(t, actual, err, "one\nTWO\nthree\nfour\nfive\n")
+}
+
+func TestParseUnifiedInsert(t *testing.T) {
+	diff := "@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n"
+
+	p, err := ParseUnified(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	actual, err := p.PatchString("one\ntwo\n")
+
+	checkOutput(t, actual, err, "one\none and a half\ntwo\n")
+}
+
+func TestParseUnifiedDeleteOnly(t *testing.T) {
+	diff := "@@ -1,3 +1,2 @@\n one\n-two\n three\n"
+
+	p, err := ParseUnified(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	actual, err := p.PatchString("one\ntwo\nthree\n")
+
+	checkOutput(t, actual, err, "one\nthree\n")
+}
+
+func TestParseUnifiedInvalidHeader(t *testing.T) {
+	_, err := ParseUnified(strings.NewReader("not a hunk header\n"))
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWriteUnifiedPreservesContextBetweenEdits(t *testing.T) {
+	input := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+
+	var p Patcher
+	p.RewriteString(4, 3, "TWO")
+	p.RewriteString(24, 3, "SIX")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(input), 3); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "@@ -1,7 +1,7 @@\n one\n-two\n+TWO\n three\n four\n five\n-six\n+SIX\n seven\n"
+	if buf.String() != expected {
+		t.Errorf("wrong diff:\nexpected: %q\nactual:   %q", expected, buf.String())
+	}
+}
+
+func TestWriteUnifiedNoTrailingNewline(t *testing.T) {
+	input := "one\ntwo\nthree"
+
+	var p Patcher
+	p.InsertString(len(input), "\nfour")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(input), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "@@ -2,2 +2,3 @@\n two\n-three\n" + noNewlineMarker + "\n+three\n+four\n" + noNewlineMarker + "\n"
+	if buf.String() != expected {
+		t.Errorf("wrong diff:\nexpected: %q\nactual:   %q", expected, buf.String())
+	}
+}
+
+func TestParseUnifiedNoTrailingNewlineRoundTrip(t *testing.T) {
+	a := "one\ntwo\nthree"
+	b := "one\ntwo\nthree\nfour"
+
+	var p Patcher
+	p.InsertString(len(a), "\nfour")
+
+	var buf bytes.Buffer
+	if err := p.WriteUnified(&buf, []byte(a), 1); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	parsed, err := ParseUnified(&buf)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	actual, err := parsed.PatchString(a)
+
+	checkOutput(t, actual, err, b)
+}
+
+func TestParseUnifiedRejectsDriftedContext(t *testing.T) {
+	diff := "@@ -1,3 +1,2 @@\n one\n-two\n three\n"
+
+	p, err := ParseUnified(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	_, err = p.PatchString("one\nTWO\nthree\n")
+
+	mismatch, ok := err.(*ContextMismatchError)
+	if !ok {
+		t.Fatalf("expected *ContextMismatchError, got %T: %v", err, err)
+	}
+	if mismatch.Offset != 0 || mismatch.Length != 3 {
+		t.Errorf("wrong offset/length: %d,%d", mismatch.Offset, mismatch.Length)
+	}
+}